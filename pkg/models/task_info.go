@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TaskInfo represents the state of a task stored in the tasks table, as
+// returned by PgQueue's Status, Dequeue and DequeueBatch operations.
+type TaskInfo struct {
+	Id             uuid.UUID
+	Typename       string
+	Payload        []byte
+	Queued         *time.Time
+	Started        *time.Time
+	Finished       *time.Time
+	Status         string
+	Error          *string
+	OrgId          string
+	RepositoryUUID uuid.UUID
+	Token          uuid.UUID
+	RequestID      string
+	Dependencies   []uuid.UUID
+
+	// Result holds the bytes written via PgQueue.ResultWriter for this task,
+	// once the worker has started (or finished) writing output.
+	Result []byte
+
+	// RetainUntil is when this task's row becomes eligible for removal by
+	// PgQueue.ReapExpired. Nil means the task is retained indefinitely.
+	RetainUntil *time.Time
+}
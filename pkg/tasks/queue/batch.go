@@ -0,0 +1,103 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"github.com/jackc/pgx/v4"
+	"github.com/rs/zerolog/log"
+)
+
+const sqlDequeueBatch = `
+	UPDATE tasks
+	SET token = gen_random_uuid(), started_at = statement_timestamp(), status = 'running'
+	WHERE id IN (
+	  SELECT id
+	  FROM ready_tasks
+	  WHERE type = ANY($1)
+	  ORDER BY priority DESC, queued_at ASC
+	  LIMIT $2
+	  FOR UPDATE SKIP LOCKED
+	)
+	RETURNING ` + taskInfoReturning
+
+// DequeueBatch atomically claims up to max ready tasks of the given types in
+// a single transaction. The returned slice is ordered by queued_at so callers
+// can process it FIFO.
+func (p *PgQueue) DequeueBatch(ctx context.Context, taskTypes []string, max int) ([]*models.TaskInfo, error) {
+	tx, err := p.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error starting a new transaction when batch dequeueing: %w", err)
+	}
+	defer func() {
+		errRollback := tx.Rollback(context.Background())
+		if errRollback != nil && !errors.Is(errRollback, pgx.ErrTxClosed) {
+			log.Logger.Error().Err(errRollback).Msg("Error rolling back batch dequeue transaction")
+		}
+	}()
+
+	rows, err := tx.Query(ctx, sqlDequeueBatch, taskTypes, max)
+	if err != nil {
+		return nil, fmt.Errorf("error batch dequeueing tasks: %w", err)
+	}
+
+	var infos []*models.TaskInfo
+	for rows.Next() {
+		info := &models.TaskInfo{}
+		err = rows.Scan(
+			&info.Id, &info.Typename, &info.Payload, &info.Queued, &info.Started, &info.Finished, &info.Status,
+			&info.Error, &info.OrgId, &info.RepositoryUUID, &info.Token, &info.RequestID, &info.Result, &info.RetainUntil,
+		)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error scanning batch dequeued task: %w", err)
+		}
+		infos = append(infos, info)
+	}
+	rows.Close()
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("error reading batch dequeued tasks: %w", rows.Err())
+	}
+
+	sortByQueuedAt(infos)
+
+	if len(infos) > 0 {
+		if err := insertHeartbeatBatch(ctx, tx, infos); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("error committing batch dequeue transaction: %w", err)
+	}
+
+	return infos, nil
+}
+
+func sortByQueuedAt(infos []*models.TaskInfo) {
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Queued.Before(*infos[j].Queued)
+	})
+}
+
+// insertHeartbeatBatch inserts one heartbeat row per claimed task via a
+// single multi-row INSERT, rather than one INSERT per task.
+func insertHeartbeatBatch(ctx context.Context, tx Transaction, infos []*models.TaskInfo) error {
+	values := make([]string, 0, len(infos))
+	args := make([]interface{}, 0, len(infos)*2)
+	for i, info := range infos {
+		values = append(values, fmt.Sprintf("($%d, $%d, statement_timestamp())", i*2+1, i*2+2))
+		args = append(args, info.Token, info.Id)
+	}
+
+	sql := "INSERT INTO task_heartbeats(token, id, heartbeat) VALUES " + strings.Join(values, ", ")
+	_, err := tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("error inserting batch heartbeats: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"github.com/google/uuid"
+)
+
+func TestSortByQueuedAtOrdersFIFO(t *testing.T) {
+	now := time.Now()
+	third := now.Add(2 * time.Second)
+	first := now
+	second := now.Add(time.Second)
+
+	infos := []*models.TaskInfo{
+		{Id: uuid.New(), Queued: &third},
+		{Id: uuid.New(), Queued: &first},
+		{Id: uuid.New(), Queued: &second},
+	}
+
+	sortByQueuedAt(infos)
+
+	if !(*infos[0].Queued).Equal(first) || !(*infos[1].Queued).Equal(second) || !(*infos[2].Queued).Equal(third) {
+		t.Fatalf("expected infos sorted FIFO by queued_at, got %v, %v, %v", infos[0].Queued, infos[1].Queued, infos[2].Queued)
+	}
+}
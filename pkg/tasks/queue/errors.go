@@ -0,0 +1,18 @@
+package queue
+
+import "errors"
+
+var (
+	ErrContextCanceled = errors.New("context was canceled")
+	ErrCanceled        = errors.New("task was canceled")
+	ErrNotRunning      = errors.New("task is not running")
+	ErrNotExist        = errors.New("task does not exist")
+	ErrRowsNotAffected = errors.New("no rows were affected")
+
+	// ErrTaskIDConflict is returned by Enqueue when the row can't be inserted:
+	// either the TaskID is already in use (ids are a primary key, so this is
+	// permanent, even after the original task finishes), or the
+	// IdempotencyKey is already in use by a pending or running task for that
+	// org (keys free up once their task finishes).
+	ErrTaskIDConflict = errors.New("a task with this id already exists, or one with this idempotency key is already pending or running")
+)
@@ -0,0 +1,141 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const sqlNotifyPayload = `SELECT pg_notify('tasks', $1)`
+
+type EventType string
+
+const (
+	EventEnqueued EventType = "enqueued"
+	EventFinished EventType = "finished"
+	EventCanceled EventType = "canceled"
+	EventRequeued EventType = "requeued"
+)
+
+// TaskEvent is the JSON payload carried by NOTIFY tasks and delivered to Subscribe callers.
+type TaskEvent struct {
+	TaskID    uuid.UUID `json:"task_id"`
+	OrgID     string    `json:"org_id"`
+	Type      EventType `json:"type"`
+	OldStatus string    `json:"old_status"`
+	NewStatus string    `json:"new_status"`
+	At        time.Time `json:"at"`
+}
+
+// EventFilter narrows which TaskEvents a Subscribe call receives; a zero-valued field matches any value.
+type EventFilter struct {
+	OrgID  string
+	TaskID uuid.UUID
+	Types  []EventType
+}
+
+func (f EventFilter) matches(e TaskEvent) bool {
+	if f.OrgID != "" && f.OrgID != e.OrgID {
+		return false
+	}
+	if f.TaskID != uuid.Nil && f.TaskID != e.TaskID {
+		return false
+	}
+	if len(f.Types) == 0 {
+		return true
+	}
+	for _, t := range f.Types {
+		if t == e.Type {
+			return true
+		}
+	}
+	return false
+}
+
+const subscriberChanBuffer = 16
+
+type subscriber struct {
+	filter EventFilter
+	ch     chan TaskEvent
+}
+
+// subscribers is a thread-safe fan-out registry of active Subscribe calls.
+type subscribers struct {
+	mutex sync.Mutex
+	subs  map[*subscriber]struct{}
+}
+
+func newSubscribers() *subscribers {
+	return &subscribers{subs: map[*subscriber]struct{}{}}
+}
+
+func (s *subscribers) add(sub *subscriber) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.subs[sub] = struct{}{}
+}
+
+func (s *subscribers) remove(sub *subscriber) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.subs, sub)
+}
+
+// dispatch drops the event for any subscriber whose buffered channel is full, rather than blocking.
+func (s *subscribers) dispatch(event TaskEvent) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for sub := range s.subs {
+		if !sub.filter.matches(event) {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			log.Logger.Warn().
+				Str("task_id", event.TaskID.String()).
+				Msg("Dropping task event for slow subscriber")
+		}
+	}
+}
+
+// Subscribe returns a channel of TaskEvents matching filter. The channel is
+// closed and the subscription removed once ctx is canceled.
+func (p *PgQueue) Subscribe(ctx context.Context, filter EventFilter) (<-chan TaskEvent, error) {
+	sub := &subscriber{
+		filter: filter,
+		ch:     make(chan TaskEvent, subscriberChanBuffer),
+	}
+	p.subscribers.add(sub)
+
+	go func() {
+		<-ctx.Done()
+		p.subscribers.remove(sub)
+		close(sub.ch)
+	}()
+
+	return sub.ch, nil
+}
+
+// publish replaces the old bare NOTIFY tasks calls with one carrying a JSON payload, so the
+// listener goroutine can both dispatch to Subscribe callers and still wake the dequeuer fan-out.
+func (p *PgQueue) publish(ctx context.Context, tx Transaction, event TaskEvent) error {
+	event.At = time.Now()
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("error marshaling task event: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, sqlNotifyPayload, string(payload))
+	if err != nil {
+		return fmt.Errorf("error notifying tasks channel: %w", err)
+	}
+
+	return nil
+}
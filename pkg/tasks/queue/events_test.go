@@ -0,0 +1,78 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestEventFilterMatches(t *testing.T) {
+	taskID := uuid.New()
+	event := TaskEvent{TaskID: taskID, OrgID: "org1", Type: EventFinished}
+
+	if !(EventFilter{}).matches(event) {
+		t.Fatalf("zero-valued filter should match any event")
+	}
+	if !(EventFilter{OrgID: "org1"}).matches(event) {
+		t.Fatalf("expected org filter to match")
+	}
+	if (EventFilter{OrgID: "org2"}).matches(event) {
+		t.Fatalf("expected org filter not to match a different org")
+	}
+	if !(EventFilter{TaskID: taskID}).matches(event) {
+		t.Fatalf("expected task id filter to match")
+	}
+	if (EventFilter{TaskID: uuid.New()}).matches(event) {
+		t.Fatalf("expected task id filter not to match a different task")
+	}
+	if !(EventFilter{Types: []EventType{EventEnqueued, EventFinished}}).matches(event) {
+		t.Fatalf("expected type filter to match one of its types")
+	}
+	if (EventFilter{Types: []EventType{EventEnqueued}}).matches(event) {
+		t.Fatalf("expected type filter not to match a type it doesn't list")
+	}
+}
+
+func TestSubscribersDispatchOnlyToMatchingSubscribers(t *testing.T) {
+	s := newSubscribers()
+
+	matching := &subscriber{filter: EventFilter{OrgID: "org1"}, ch: make(chan TaskEvent, 1)}
+	nonMatching := &subscriber{filter: EventFilter{OrgID: "org2"}, ch: make(chan TaskEvent, 1)}
+	s.add(matching)
+	s.add(nonMatching)
+
+	s.dispatch(TaskEvent{OrgID: "org1", Type: EventEnqueued})
+
+	select {
+	case <-matching.ch:
+	default:
+		t.Fatalf("expected matching subscriber to receive the event")
+	}
+	select {
+	case <-nonMatching.ch:
+		t.Fatalf("expected non-matching subscriber not to receive the event")
+	default:
+	}
+
+	s.remove(matching)
+	s.dispatch(TaskEvent{OrgID: "org1", Type: EventEnqueued})
+	select {
+	case <-matching.ch:
+		t.Fatalf("expected removed subscriber not to receive further events")
+	default:
+	}
+}
+
+func TestSubscribersDispatchDropsWhenBufferFull(t *testing.T) {
+	s := newSubscribers()
+	sub := &subscriber{ch: make(chan TaskEvent, 1)}
+	s.add(sub)
+
+	s.dispatch(TaskEvent{Type: EventEnqueued})
+	s.dispatch(TaskEvent{Type: EventFinished}) // must not block even though the buffer is already full
+
+	event := <-sub.ch
+	if event.Type != EventEnqueued {
+		t.Fatalf("expected the first event to be retained, got %v", event.Type)
+	}
+}
@@ -0,0 +1,108 @@
+package queue
+
+import (
+	"context"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+// fakeRow is a minimal pgx.Row for tests to control what Scan populates.
+type fakeRow struct {
+	scanFn func(dest ...interface{}) error
+}
+
+func (r fakeRow) Scan(dest ...interface{}) error {
+	return r.scanFn(dest...)
+}
+
+// fakeTx is a minimal pgx.Tx, embedding pgx.Tx so it still satisfies the
+// interface; only the methods PgQueue actually calls are implemented.
+type fakeTx struct {
+	pgx.Tx
+	execFn     func(sql string, args []interface{}) (pgconn.CommandTag, error)
+	queryRowFn func(sql string, args []interface{}) pgx.Row
+	closed     bool
+}
+
+func (f *fakeTx) Exec(_ context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	if f.execFn != nil {
+		return f.execFn(sql, args)
+	}
+	return pgconn.CommandTag(""), nil
+}
+
+func (f *fakeTx) QueryRow(_ context.Context, sql string, args ...interface{}) pgx.Row {
+	return f.queryRowFn(sql, args)
+}
+
+func (f *fakeTx) Commit(context.Context) error {
+	f.closed = true
+	return nil
+}
+
+func (f *fakeTx) Rollback(context.Context) error {
+	if f.closed {
+		return pgx.ErrTxClosed
+	}
+	f.closed = true
+	return nil
+}
+
+// fakeConn is a minimal Connection, only implementing what Enqueue uses.
+type fakeConn struct {
+	tx     *fakeTx
+	execFn func(sql string, args []interface{}) (pgconn.CommandTag, error)
+}
+
+func (c *fakeConn) Begin(context.Context) (pgx.Tx, error) {
+	return c.tx, nil
+}
+
+func (c *fakeConn) Exec(_ context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	if c.execFn != nil {
+		return c.execFn(sql, args)
+	}
+	return pgconn.CommandTag(""), nil
+}
+
+func (c *fakeConn) Query(context.Context, string, ...interface{}) (pgx.Rows, error) {
+	panic("fakeConn.Query not implemented")
+}
+
+func (c *fakeConn) QueryRow(context.Context, string, ...interface{}) pgx.Row {
+	panic("fakeConn.QueryRow not implemented")
+}
+
+func (c *fakeConn) Conn() *pgx.Conn {
+	return nil
+}
+
+func (c *fakeConn) Release() {}
+
+// fakePool is a minimal Pool, only implementing what each test drives.
+type fakePool struct {
+	conn       *fakeConn
+	execFn     func(sql string, args []interface{}) (pgconn.CommandTag, error)
+	queryRowFn func(sql string, args []interface{}) pgx.Row
+}
+
+func (p *fakePool) Acquire(context.Context) (Connection, error) {
+	return p.conn, nil
+}
+
+func (p *fakePool) Begin(context.Context) (pgx.Tx, error) {
+	panic("fakePool.Begin not implemented")
+}
+
+func (p *fakePool) Exec(_ context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	return p.execFn(sql, args)
+}
+
+func (p *fakePool) Query(context.Context, string, ...interface{}) (pgx.Rows, error) {
+	panic("fakePool.Query not implemented")
+}
+
+func (p *fakePool) QueryRow(_ context.Context, sql string, args ...interface{}) pgx.Row {
+	return p.queryRowFn(sql, args)
+}
@@ -0,0 +1,33 @@
+package queue
+
+import "github.com/google/uuid"
+
+// EnqueueOptions customizes a single Enqueue call.
+type EnqueueOptions struct {
+	// TaskID, when set, is used as the enqueued task's id instead of a
+	// randomly generated one. Unlike IdempotencyKey, reusing a TaskID
+	// conflicts forever, even after the original task finishes, since ids
+	// are a primary key.
+	TaskID uuid.UUID
+
+	// IdempotencyKey, when set, must be unique per org among tasks that
+	// are still pending or running. Enqueueing with a key that is
+	// already in use by such a task returns ErrTaskIDConflict; the key
+	// frees up once that task finishes.
+	IdempotencyKey string
+}
+
+// EnqueueOption mutates an EnqueueOptions.
+type EnqueueOption func(*EnqueueOptions)
+
+func WithTaskID(id uuid.UUID) EnqueueOption {
+	return func(o *EnqueueOptions) {
+		o.TaskID = id
+	}
+}
+
+func WithIdempotencyKey(key string) EnqueueOption {
+	return func(o *EnqueueOptions) {
+		o.IdempotencyKey = key
+	}
+}
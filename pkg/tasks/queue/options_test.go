@@ -0,0 +1,41 @@
+package queue
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+func TestEnqueueOptionsApply(t *testing.T) {
+	id := uuid.New()
+	var opts EnqueueOptions
+	for _, opt := range []EnqueueOption{WithTaskID(id), WithIdempotencyKey("key-1")} {
+		opt(&opts)
+	}
+
+	if opts.TaskID != id {
+		t.Fatalf("expected TaskID %v, got %v", id, opts.TaskID)
+	}
+	if opts.IdempotencyKey != "key-1" {
+		t.Fatalf("expected IdempotencyKey %q, got %q", "key-1", opts.IdempotencyKey)
+	}
+}
+
+func TestEnqueueTranslatesNoRowsToTaskIDConflict(t *testing.T) {
+	tx := &fakeTx{
+		queryRowFn: func(sql string, args []interface{}) pgx.Row {
+			return fakeRow{scanFn: func(dest ...interface{}) error { return pgx.ErrNoRows }}
+		},
+	}
+	p := &PgQueue{
+		Pool:        &fakePool{conn: &fakeConn{tx: tx}},
+		subscribers: newSubscribers(),
+	}
+
+	_, err := p.Enqueue(&Task{OrgId: "org1"}, WithTaskID(uuid.New()))
+	if !errors.Is(err, ErrTaskIDConflict) {
+		t.Fatalf("expected ErrTaskIDConflict, got %v", err)
+	}
+}
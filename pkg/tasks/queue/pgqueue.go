@@ -3,6 +3,7 @@ package queue
 import (
 	"container/list"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"sync"
@@ -18,14 +19,18 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-const taskInfoReturning = ` id, type, payload, queued_at, started_at, finished_at, status, error, org_id, repository_uuid, token, request_id ` // fields to return when returning taskInfo
+const taskInfoReturning = ` id, type, payload, queued_at, started_at, finished_at, status, error, org_id, repository_uuid, token, request_id, result, retain_until ` // fields to return when returning taskInfo
 
 const (
 	sqlNotify   = `NOTIFY tasks`
 	sqlListen   = `LISTEN tasks`
 	sqlUnlisten = `UNLISTEN tasks`
 
-	sqlEnqueue = `INSERT INTO tasks(id, type, payload, queued_at, org_id, repository_uuid, status, request_id) VALUES ($1, $2, $3, statement_timestamp(), $4, $5, $6, $7)`
+	sqlEnqueue = `
+		INSERT INTO tasks(id, type, payload, queued_at, org_id, repository_uuid, status, request_id, retention, idempotency_key, not_before, priority, max_retries, backoff_base)
+		VALUES ($1, $2, $3, statement_timestamp(), $4, $5, $6, $7, $8::bigint * INTERVAL '1 microsecond', $9, $10, $11, $12, $13::bigint * INTERVAL '1 microsecond')
+		ON CONFLICT DO NOTHING
+		RETURNING id`
 	sqlDequeue = `
 		UPDATE tasks
 		SET token = $1, started_at = statement_timestamp(), status = 'running'
@@ -35,6 +40,7 @@ const (
 			  -- use ANY here, because "type in ()" doesn't work with bound parameters
 			  -- literal syntax for this is '{"a", "b"}': https://www.postgresql.org/docs/13/arrays.html
 		  WHERE type = ANY($2)
+		  ORDER BY priority DESC, queued_at ASC
 		  LIMIT 1
 		  FOR UPDATE SKIP LOCKED
 		)
@@ -80,14 +86,20 @@ const (
                 SELECT id, status FROM tasks WHERE token = $1`
 	sqlFinishTask = `
 		UPDATE tasks
-		SET finished_at = statement_timestamp(), status = $1, error = $2
+		SET finished_at = statement_timestamp(), status = $1, error = $2,
+			retain_until = CASE WHEN retention IS NULL THEN NULL ELSE statement_timestamp() + retention END
 		WHERE id = $3 AND finished_at IS NULL
 		RETURNING finished_at`
+	sqlSelectTaskStatusForUpdate = `
+		SELECT status
+		FROM tasks
+		WHERE id = $1
+		FOR UPDATE`
 	sqlCancelTask = `
 		UPDATE tasks
 		SET status = 'canceled'
 		WHERE id = $1 AND finished_at IS NULL
-		RETURNING type, started_at`
+		RETURNING type, started_at, org_id`
 	sqlUpdatePayload = `
 		UPDATE tasks
 		SET payload = $1
@@ -138,9 +150,11 @@ type Connection interface {
 
 // PgQueue a task queue backed by postgres, using pgxpool.Pool using a wrapper (PgxPoolWrapper) that implements a Pool interface
 type PgQueue struct {
-	Pool         Pool
-	dequeuers    *dequeuers
-	stopListener func()
+	Pool          Pool
+	dequeuers     *dequeuers
+	subscribers   *subscribers
+	wakeScheduler chan struct{}
+	stopListener  func()
 }
 
 // thread-safe list of dequeuers
@@ -210,13 +224,16 @@ func NewPgQueue(url string) (PgQueue, error) {
 	listenContext, cancel := context.WithCancel(context.Background())
 	poolWrapper = &PgxPoolWrapper{pool: pool}
 	q := PgQueue{
-		Pool:         poolWrapper,
-		dequeuers:    newDequeuers(),
-		stopListener: cancel,
+		Pool:          poolWrapper,
+		dequeuers:     newDequeuers(),
+		subscribers:   newSubscribers(),
+		wakeScheduler: make(chan struct{}, 1),
+		stopListener:  cancel,
 	}
 
 	listenerReady := make(chan struct{})
 	go q.listen(listenContext, listenerReady)
+	go q.scheduler(listenContext)
 
 	// wait for the listener to become ready
 	<-listenerReady
@@ -271,18 +288,41 @@ func (q *PgQueue) waitAndNotify(ctx context.Context) error {
 		panic(fmt.Errorf("error listening on tasks channel: %v", err))
 	}
 
-	_, err = conn.Conn().WaitForNotification(ctx)
+	notification, err := conn.Conn().WaitForNotification(ctx)
 	if err != nil {
 		return err
 	}
 
+	if notification.Payload != "" {
+		var event TaskEvent
+		if err := json.Unmarshal([]byte(notification.Payload), &event); err != nil {
+			log.Logger.Error().Err(err).Msg("Error decoding task event payload")
+		} else {
+			q.subscribers.dispatch(event)
+		}
+	}
+
 	// something happened in the database, notify all dequeuers
 	q.dequeuers.notifyAll()
 	return nil
 }
 
-func (p *PgQueue) Enqueue(task *Task) (uuid.UUID, error) {
-	taskID := uuid.New()
+func (p *PgQueue) Enqueue(task *Task, opts ...EnqueueOption) (uuid.UUID, error) {
+	var options EnqueueOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	taskID := options.TaskID
+	if taskID == uuid.Nil {
+		taskID = uuid.New()
+	}
+
+	var idempotencyKey *string
+	if options.IdempotencyKey != "" {
+		idempotencyKey = &options.IdempotencyKey
+	}
+
 	conn, err := p.Pool.Acquire(context.Background())
 	if err != nil {
 		return uuid.Nil, err
@@ -300,8 +340,31 @@ func (p *PgQueue) Enqueue(task *Task) (uuid.UUID, error) {
 		}
 	}()
 
-	_, err = tx.Exec(context.Background(), sqlEnqueue,
-		taskID.String(), task.Typename, task.Payload, task.OrgId, task.RepositoryUUID, config.TaskStatusPending, task.RequestID)
+	var retention *int64
+	if task.Retention > 0 {
+		microseconds := task.Retention.Microseconds()
+		retention = &microseconds
+	}
+
+	var notBefore *time.Time
+	if !task.RunAfter.IsZero() {
+		notBefore = &task.RunAfter
+	}
+
+	var backoffBase *int64
+	if task.BackoffBase > 0 {
+		microseconds := task.BackoffBase.Microseconds()
+		backoffBase = &microseconds
+	}
+
+	var insertedID uuid.UUID
+	err = tx.QueryRow(context.Background(), sqlEnqueue,
+		taskID.String(), task.Typename, task.Payload, task.OrgId, task.RepositoryUUID, config.TaskStatusPending, task.RequestID,
+		retention, idempotencyKey, notBefore, task.Priority, task.MaxRetries, backoffBase).
+		Scan(&insertedID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, ErrTaskIDConflict
+	}
 	if err != nil {
 		return uuid.Nil, fmt.Errorf("error enqueuing task: %w", err)
 	}
@@ -313,7 +376,12 @@ func (p *PgQueue) Enqueue(task *Task) (uuid.UUID, error) {
 		}
 	}
 
-	_, err = conn.Exec(context.Background(), sqlNotify)
+	err = p.publish(context.Background(), conn, TaskEvent{
+		TaskID:    taskID,
+		OrgID:     task.OrgId,
+		Type:      EventEnqueued,
+		NewStatus: config.TaskStatusPending,
+	})
 	if err != nil {
 		return uuid.Nil, fmt.Errorf("error notifying tasks channel: %w", err)
 	}
@@ -323,6 +391,10 @@ func (p *PgQueue) Enqueue(task *Task) (uuid.UUID, error) {
 		return uuid.Nil, fmt.Errorf("unable to commit database transaction: %w", err)
 	}
 
+	if notBefore != nil {
+		p.wakeSchedulerNonBlocking()
+	}
+
 	return taskID, nil
 }
 
@@ -382,7 +454,7 @@ func (p *PgQueue) dequeueMaybe(ctx context.Context, token uuid.UUID, taskTypes [
 
 	err = tx.QueryRow(ctx, sqlDequeue, token, taskTypes).Scan(
 		&info.Id, &info.Typename, &info.Payload, &info.Queued, &info.Started, &info.Finished, &info.Status,
-		&info.Error, &info.OrgId, &info.RepositoryUUID, &info.Token, &info.RequestID,
+		&info.Error, &info.OrgId, &info.RepositoryUUID, &info.Token, &info.RequestID, &info.Result, &info.RetainUntil,
 	)
 
 	if err != nil && errors.Is(err, pgx.ErrNoRows) {
@@ -470,7 +542,7 @@ func (p *PgQueue) Status(taskId uuid.UUID) (*models.TaskInfo, error) {
 	defer conn.Release()
 	err = conn.QueryRow(context.Background(), sqlQueryTaskStatus, taskId).Scan(
 		&info.Id, &info.Typename, &info.Payload, &info.Queued, &info.Started, &info.Finished, &info.Status,
-		&info.Error, &info.OrgId, &info.RepositoryUUID, &info.Token, &info.RequestID,
+		&info.Error, &info.OrgId, &info.RepositoryUUID, &info.Token, &info.RequestID, &info.Result, &info.RetainUntil,
 	)
 	if err != nil {
 		return nil, err
@@ -540,7 +612,13 @@ func (p *PgQueue) Finish(taskId uuid.UUID, taskError error) error {
 		return fmt.Errorf("error finishing task %s: %v", taskId, err)
 	}
 
-	_, err = tx.Exec(context.Background(), sqlNotify)
+	err = p.publish(context.Background(), tx, TaskEvent{
+		TaskID:    taskId,
+		OrgID:     info.OrgId,
+		Type:      EventFinished,
+		OldStatus: config.TaskStatusRunning,
+		NewStatus: status,
+	})
 	if err != nil {
 		return fmt.Errorf("error notifying tasks channel: %v", err)
 	}
@@ -554,16 +632,38 @@ func (p *PgQueue) Finish(taskId uuid.UUID, taskError error) error {
 }
 
 func (p *PgQueue) Cancel(taskId uuid.UUID) error {
-	var err error
 	var started *time.Time
 	var taskType string
+	var orgId string
+	var oldStatus string
+
 	conn, err := p.Pool.Acquire(context.Background())
 	if err != nil {
 		return err
 	}
 	defer conn.Release()
-	err = conn.QueryRow(context.Background(), sqlCancelTask, taskId).Scan(&taskType, &started)
-	if err == pgx.ErrNoRows {
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return fmt.Errorf("error starting database transaction: %w", err)
+	}
+	defer func() {
+		errRollback := tx.Rollback(context.Background())
+		if errRollback != nil && !errors.Is(errRollback, pgx.ErrTxClosed) {
+			err = fmt.Errorf("error rolling back cancel transaction: %w: %v", errRollback, err)
+		}
+	}()
+
+	err = tx.QueryRow(context.Background(), sqlSelectTaskStatusForUpdate, taskId).Scan(&oldStatus)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotRunning
+	}
+	if err != nil {
+		return fmt.Errorf("error reading task %s status: %w", taskId, err)
+	}
+
+	err = tx.QueryRow(context.Background(), sqlCancelTask, taskId).Scan(&taskType, &started, &orgId)
+	if errors.Is(err, pgx.ErrNoRows) {
 		return ErrNotRunning
 	}
 	if err != nil {
@@ -572,7 +672,17 @@ func (p *PgQueue) Cancel(taskId uuid.UUID) error {
 
 	log.Logger.Info().Msg(fmt.Sprintf("[Canceling Task] Task Type: %v | Task ID: %v", taskType, taskId.String()))
 
-	return nil
+	if err = p.publish(context.Background(), tx, TaskEvent{
+		TaskID:    taskId,
+		OrgID:     orgId,
+		Type:      EventCanceled,
+		OldStatus: oldStatus,
+		NewStatus: config.TaskStatusCanceled,
+	}); err != nil {
+		return fmt.Errorf("error notifying tasks channel: %w", err)
+	}
+
+	return tx.Commit(context.Background())
 }
 
 func (p *PgQueue) Requeue(taskId uuid.UUID) error {
@@ -616,7 +726,13 @@ func (p *PgQueue) Requeue(taskId uuid.UUID) error {
 		return ErrNotExist
 	}
 
-	_, err = tx.Exec(context.Background(), sqlNotify)
+	err = p.publish(context.Background(), tx, TaskEvent{
+		TaskID:    taskId,
+		OrgID:     info.OrgId,
+		Type:      EventRequeued,
+		OldStatus: config.TaskStatusRunning,
+		NewStatus: config.TaskStatusPending,
+	})
 	if err != nil {
 		return fmt.Errorf("error notifying tasks channel: %v", err)
 	}
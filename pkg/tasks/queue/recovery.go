@@ -0,0 +1,167 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	sqlQueryRetryInfo = `
+		SELECT attempts, max_retries, backoff_base, org_id
+		FROM tasks
+		WHERE id = $1`
+	sqlRequeueWithBackoff = `
+		UPDATE tasks
+		SET started_at = NULL, token = NULL, status = 'pending', attempts = attempts + 1, not_before = $2
+		WHERE id = $1 AND started_at IS NOT NULL AND finished_at IS NULL`
+)
+
+// defaultBackoffBase is used when a task was enqueued without a BackoffBase.
+const defaultBackoffBase = time.Second
+
+// RecoveryConfig controls RunRecovery's liveness detection and sweep cadence.
+type RecoveryConfig struct {
+	HeartbeatPeriod  time.Duration
+	MissedHeartbeats int
+	CleanupPeriod    time.Duration
+
+	// MaxBackoff caps the exponential backoff delay applied before a recovered task is
+	// retried. Zero means uncapped.
+	MaxBackoff time.Duration
+}
+
+func (c RecoveryConfig) staleAfter() time.Duration {
+	missed := c.MissedHeartbeats
+	if missed < 1 {
+		// guard against MissedHeartbeats being left unset, which would otherwise mark every
+		// running task stale on every tick.
+		missed = 1
+	}
+	return c.HeartbeatPeriod * time.Duration(missed)
+}
+
+// RunRecovery periodically requeues tasks of workers that stopped heartbeating, delayed via
+// exponential backoff, or marks them failed once MaxRetries is exhausted. Blocks until ctx is canceled;
+// if cfg.CleanupPeriod is not positive it logs an error and returns at once without recovering anything.
+func (p *PgQueue) RunRecovery(ctx context.Context, cfg RecoveryConfig) {
+	if cfg.CleanupPeriod <= 0 {
+		log.Logger.Error().Msg("RunRecovery requires a positive CleanupPeriod; recovery is disabled")
+		return
+	}
+
+	ticker := time.NewTicker(cfg.CleanupPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for _, token := range p.Heartbeats(cfg.staleAfter()) {
+			if err := p.recoverToken(cfg, token); err != nil {
+				log.Logger.Error().Err(err).Msg("Error recovering stale task")
+			}
+		}
+	}
+}
+
+func (p *PgQueue) recoverToken(cfg RecoveryConfig, token uuid.UUID) error {
+	taskId, _, err := p.IdFromToken(token)
+	if err != nil {
+		return fmt.Errorf("error looking up task for stale token %s: %w", token, err)
+	}
+
+	var attempts, maxRetries int
+	var backoffBase *time.Duration
+	var orgId string
+	err = p.Pool.QueryRow(context.Background(), sqlQueryRetryInfo, taskId).Scan(&attempts, &maxRetries, &backoffBase, &orgId)
+	if err != nil {
+		return fmt.Errorf("error reading retry info for task %s: %w", taskId, err)
+	}
+
+	if attempts >= maxRetries {
+		if err := p.Finish(taskId, fmt.Errorf("task abandoned by dead worker after %d attempts", attempts)); err != nil {
+			return fmt.Errorf("error failing exhausted task %s: %w", taskId, err)
+		}
+		return nil
+	}
+
+	base := defaultBackoffBase
+	if backoffBase != nil {
+		base = *backoffBase
+	}
+	nextRetryAt := time.Now().Add(backoffDuration(base, cfg.MaxBackoff, attempts))
+
+	if err := p.requeueWithBackoff(taskId, orgId, nextRetryAt); err != nil {
+		return fmt.Errorf("error requeueing recovered task %s: %w", taskId, err)
+	}
+
+	return nil
+}
+
+func (p *PgQueue) requeueWithBackoff(taskId uuid.UUID, orgId string, nextRetryAt time.Time) error {
+	tx, err := p.Pool.Begin(context.Background())
+	if err != nil {
+		return fmt.Errorf("error starting database transaction: %w", err)
+	}
+	defer func() {
+		errRollback := tx.Rollback(context.Background())
+		if errRollback != nil {
+			log.Logger.Error().Err(errRollback).Msg("Error rolling back recovery requeue transaction")
+		}
+	}()
+
+	_, err = tx.Exec(context.Background(), sqlDeleteHeartbeat, taskId)
+	if err != nil {
+		return fmt.Errorf("error removing task %s from heartbeats: %w", taskId, err)
+	}
+
+	tag, err := tx.Exec(context.Background(), sqlRequeueWithBackoff, taskId, nextRetryAt)
+	if err != nil {
+		return fmt.Errorf("error requeueing task %s: %w", taskId, err)
+	}
+	if tag.RowsAffected() != 1 {
+		return ErrNotExist
+	}
+
+	err = p.publish(context.Background(), tx, TaskEvent{
+		TaskID:    taskId,
+		OrgID:     orgId,
+		Type:      EventRequeued,
+		OldStatus: config.TaskStatusRunning,
+		NewStatus: config.TaskStatusPending,
+	})
+	if err != nil {
+		return fmt.Errorf("error notifying tasks channel: %w", err)
+	}
+
+	if err := tx.Commit(context.Background()); err != nil {
+		return err
+	}
+
+	p.wakeSchedulerNonBlocking()
+
+	return nil
+}
+
+// backoffDuration computes base * 2^attempts, capped at max (if set), with
+// full jitter applied so recovered workers don't all retry in lockstep.
+func backoffDuration(base, max time.Duration, attempts int) time.Duration {
+	d := time.Duration(float64(base) * math.Pow(2, float64(attempts)))
+	if max > 0 && d > max {
+		d = max
+	}
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(d)))
+}
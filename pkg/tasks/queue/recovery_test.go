@@ -0,0 +1,67 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBackoffDurationCapsAtMax(t *testing.T) {
+	base := time.Second
+	max := 10 * time.Second
+
+	for attempts := 0; attempts < 10; attempts++ {
+		d := backoffDuration(base, max, attempts)
+		if d < 0 {
+			t.Fatalf("backoffDuration returned a negative duration: %v", d)
+		}
+		if d > max {
+			t.Fatalf("backoffDuration exceeded max backoff: got %v, want <= %v", d, max)
+		}
+	}
+}
+
+func TestBackoffDurationGrowsExponentially(t *testing.T) {
+	base := time.Second
+
+	// uncapped, so the ceiling for attempt N is base*2^N; jitter only ever
+	// shrinks it, so the attempt-3 ceiling must be larger than attempt-0's.
+	ceiling := func(attempts int) time.Duration {
+		d := base
+		for i := 0; i < attempts; i++ {
+			d *= 2
+		}
+		return d
+	}
+
+	if ceiling(3) <= ceiling(0) {
+		t.Fatalf("expected backoff ceiling to grow with attempts")
+	}
+
+	for attempts := 0; attempts < 5; attempts++ {
+		if d := backoffDuration(base, 0, attempts); d > ceiling(attempts) {
+			t.Fatalf("attempt %d: backoffDuration %v exceeded expected ceiling %v", attempts, d, ceiling(attempts))
+		}
+	}
+}
+
+func TestStaleAfterGuardsZeroMissedHeartbeats(t *testing.T) {
+	cfg := RecoveryConfig{HeartbeatPeriod: 10 * time.Second}
+	if got := cfg.staleAfter(); got != 10*time.Second {
+		t.Fatalf("expected MissedHeartbeats to default to 1, got staleAfter() = %v", got)
+	}
+}
+
+func TestRunRecoveryReturnsImmediatelyForNonPositiveCleanupPeriod(t *testing.T) {
+	done := make(chan struct{})
+	go func() {
+		(&PgQueue{}).RunRecovery(context.Background(), RecoveryConfig{})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("expected RunRecovery to return immediately when CleanupPeriod is not positive")
+	}
+}
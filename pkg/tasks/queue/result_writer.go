@@ -0,0 +1,68 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+const (
+	sqlAppendResult = `
+		UPDATE tasks
+		SET result = COALESCE(result, '') || $1
+		WHERE id = $2`
+	sqlQueryResult = `
+		SELECT result
+		FROM tasks
+		WHERE id = $1`
+	sqlReapExpired = `
+		DELETE FROM tasks
+		WHERE retain_until < statement_timestamp()`
+)
+
+type ResultWriter interface {
+	Write(p []byte) (int, error)
+}
+
+type pgResultWriter struct {
+	pool   Pool
+	taskId uuid.UUID
+}
+
+func (w *pgResultWriter) Write(p []byte) (int, error) {
+	tag, err := w.pool.Exec(context.Background(), sqlAppendResult, p, w.taskId)
+	if err != nil {
+		return 0, fmt.Errorf("error appending result for task %s: %w", w.taskId, err)
+	}
+	if tag.RowsAffected() != 1 {
+		return 0, ErrNotExist
+	}
+	return len(p), nil
+}
+
+func (p *PgQueue) ResultWriter(taskId uuid.UUID) ResultWriter {
+	return &pgResultWriter{pool: p.Pool, taskId: taskId}
+}
+
+func (p *PgQueue) Result(taskId uuid.UUID) ([]byte, error) {
+	var result []byte
+	err := p.Pool.QueryRow(context.Background(), sqlQueryResult, taskId).Scan(&result)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotExist
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading result for task %s: %w", taskId, err)
+	}
+	return result, nil
+}
+
+func (p *PgQueue) ReapExpired(ctx context.Context) error {
+	_, err := p.Pool.Exec(ctx, sqlReapExpired)
+	if err != nil {
+		return fmt.Errorf("error reaping expired tasks: %w", err)
+	}
+	return nil
+}
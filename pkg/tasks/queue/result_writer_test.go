@@ -0,0 +1,125 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+func TestResultWriterWriteAppendsResult(t *testing.T) {
+	taskId := uuid.New()
+	var gotSQL string
+	var gotArgs []interface{}
+	pool := &fakePool{
+		execFn: func(sql string, args []interface{}) (pgconn.CommandTag, error) {
+			gotSQL = sql
+			gotArgs = args
+			return pgconn.CommandTag("UPDATE 1"), nil
+		},
+	}
+	w := &pgResultWriter{pool: pool, taskId: taskId}
+
+	n, err := w.Write([]byte("hello"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 5 {
+		t.Fatalf("expected 5 bytes written, got %d", n)
+	}
+	if gotSQL != sqlAppendResult {
+		t.Fatalf("expected sqlAppendResult to be used, got %q", gotSQL)
+	}
+	if gotArgs[1] != taskId {
+		t.Fatalf("expected taskId bound as the second argument, got %v", gotArgs[1])
+	}
+}
+
+func TestResultWriterWriteReturnsErrNotExistWhenNoRowAffected(t *testing.T) {
+	pool := &fakePool{
+		execFn: func(sql string, args []interface{}) (pgconn.CommandTag, error) {
+			return pgconn.CommandTag("UPDATE 0"), nil
+		},
+	}
+	w := &pgResultWriter{pool: pool, taskId: uuid.New()}
+
+	_, err := w.Write([]byte("hello"))
+	if !errors.Is(err, ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got %v", err)
+	}
+}
+
+func TestResultTranslatesNoRowsToErrNotExist(t *testing.T) {
+	p := &PgQueue{Pool: &fakePool{
+		queryRowFn: func(sql string, args []interface{}) pgx.Row {
+			return fakeRow{scanFn: func(dest ...interface{}) error { return pgx.ErrNoRows }}
+		},
+	}}
+
+	_, err := p.Result(uuid.New())
+	if !errors.Is(err, ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got %v", err)
+	}
+}
+
+func TestEnqueueBindsRetentionAndBackoffBaseAsMicroseconds(t *testing.T) {
+	taskID := uuid.New()
+	var gotArgs []interface{}
+	tx := &fakeTx{
+		queryRowFn: func(sql string, args []interface{}) pgx.Row {
+			gotArgs = args
+			return fakeRow{scanFn: func(dest ...interface{}) error {
+				*dest[0].(*uuid.UUID) = taskID
+				return nil
+			}}
+		},
+	}
+	p := &PgQueue{
+		Pool:          &fakePool{conn: &fakeConn{tx: tx}},
+		subscribers:   newSubscribers(),
+		wakeScheduler: make(chan struct{}, 1),
+	}
+
+	task := &Task{OrgId: "org1", Retention: 2 * time.Second, BackoffBase: 3 * time.Second}
+	id, err := p.Enqueue(task, WithTaskID(taskID))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id != taskID {
+		t.Fatalf("expected returned id %v, got %v", taskID, id)
+	}
+
+	retention, ok := gotArgs[7].(*int64)
+	if !ok || retention == nil || *retention != task.Retention.Microseconds() {
+		t.Fatalf("expected retention bound as a microsecond count, got %#v", gotArgs[7])
+	}
+	backoffBase, ok := gotArgs[12].(*int64)
+	if !ok || backoffBase == nil || *backoffBase != task.BackoffBase.Microseconds() {
+		t.Fatalf("expected backoff_base bound as a microsecond count, got %#v", gotArgs[12])
+	}
+}
+
+func TestReapExpiredIssuesReapQuery(t *testing.T) {
+	called := false
+	pool := &fakePool{
+		execFn: func(sql string, args []interface{}) (pgconn.CommandTag, error) {
+			called = true
+			if sql != sqlReapExpired {
+				t.Fatalf("expected sqlReapExpired to be used, got %q", sql)
+			}
+			return pgconn.CommandTag("DELETE 3"), nil
+		},
+	}
+	p := &PgQueue{Pool: pool}
+
+	if err := p.ReapExpired(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !called {
+		t.Fatalf("expected ReapExpired to issue sqlReapExpired")
+	}
+}
@@ -0,0 +1,79 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const sqlQueryNextScheduled = `
+	SELECT MIN(not_before)
+	FROM tasks
+	WHERE status = 'pending' AND not_before IS NOT NULL AND not_before > statement_timestamp()`
+
+// schedulerMaxWait bounds how long the scheduler ever sleeps between checks, so a
+// nextScheduledWait query error doesn't leave it sleeping forever.
+const schedulerMaxWait = 30 * time.Second
+
+func (p *PgQueue) scheduler(ctx context.Context) {
+	for {
+		wait, err := p.nextScheduledWait(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Logger.Error().Err(err).Msg("Error checking for next scheduled task")
+			wait = schedulerMaxWait
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.wakeScheduler:
+			// Enqueue or a recovery requeue set a not_before that may be earlier than what we're sleeping
+			// towards; recompute the wait instead of sleeping the rest of it out.
+			continue
+		case <-time.After(wait):
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		if _, err := p.Pool.Exec(ctx, sqlNotify); err != nil {
+			log.Logger.Error().Err(err).Msg("Error notifying tasks channel from scheduler")
+		}
+	}
+}
+
+func (p *PgQueue) nextScheduledWait(ctx context.Context) (time.Duration, error) {
+	var next *time.Time
+	err := p.Pool.QueryRow(ctx, sqlQueryNextScheduled).Scan(&next)
+	if err != nil {
+		return 0, fmt.Errorf("error querying next scheduled task: %w", err)
+	}
+	return computeWait(next, schedulerMaxWait), nil
+}
+
+func computeWait(next *time.Time, max time.Duration) time.Duration {
+	if next == nil {
+		return max
+	}
+	wait := time.Until(*next)
+	if wait < 0 {
+		return 0
+	}
+	if wait < max {
+		return wait
+	}
+	return max
+}
+
+func (p *PgQueue) wakeSchedulerNonBlocking() {
+	select {
+	case p.wakeScheduler <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,65 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
+
+func TestComputeWait(t *testing.T) {
+	max := 30 * time.Second
+
+	if got := computeWait(nil, max); got != max {
+		t.Fatalf("expected max wait when nothing is scheduled, got %v", got)
+	}
+
+	past := time.Now().Add(-time.Second)
+	if got := computeWait(&past, max); got != 0 {
+		t.Fatalf("expected zero wait for a not_before already in the past, got %v", got)
+	}
+
+	soon := time.Now().Add(5 * time.Second)
+	if got := computeWait(&soon, max); got <= 0 || got > 5*time.Second {
+		t.Fatalf("expected a wait close to 5s, got %v", got)
+	}
+
+	far := time.Now().Add(time.Hour)
+	if got := computeWait(&far, max); got != max {
+		t.Fatalf("expected wait capped at %v, got %v", max, got)
+	}
+}
+
+func TestWakeSchedulerNonBlockingDoesNotBlockWhenFull(t *testing.T) {
+	p := &PgQueue{wakeScheduler: make(chan struct{}, 1)}
+
+	p.wakeSchedulerNonBlocking()
+	p.wakeSchedulerNonBlocking() // must not block even though the buffer is already full
+
+	select {
+	case <-p.wakeScheduler:
+	default:
+		t.Fatalf("expected a pending wake signal")
+	}
+}
+
+func TestNextScheduledWaitUsesQueryResult(t *testing.T) {
+	wantNext := time.Now().Add(5 * time.Second)
+	p := &PgQueue{Pool: &fakePool{
+		queryRowFn: func(sql string, args []interface{}) pgx.Row {
+			return fakeRow{scanFn: func(dest ...interface{}) error {
+				*dest[0].(**time.Time) = &wantNext
+				return nil
+			}}
+		},
+	}}
+
+	wait, err := p.nextScheduledWait(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if wait <= 0 || wait > 5*time.Second {
+		t.Fatalf("expected a wait close to 5s, got %v", wait)
+	}
+}
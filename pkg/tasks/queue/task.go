@@ -0,0 +1,22 @@
+package queue
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Task describes a unit of work to be enqueued onto the PgQueue.
+type Task struct {
+	Typename       string
+	Payload        interface{}
+	OrgId          string
+	RepositoryUUID uuid.UUID
+	RequestID      string
+	Dependencies   []uuid.UUID
+	Retention      time.Duration
+	RunAfter       time.Time
+	Priority       int
+	MaxRetries     int
+	BackoffBase    time.Duration
+}